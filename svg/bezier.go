@@ -1,9 +1,204 @@
 package svg
 
-import "math"
+import (
+	"math"
+	"sort"
+)
 
-const quadLengthApproximationInterval = 0.01
-const cubicLengthApproximationInterval = 0.005
+// maxFlattenDepth bounds the recursion of the de Casteljau flattening so
+// that degenerate curves (e.g. coincident control points) can't blow the
+// stack; 32 levels is far beyond what any reasonable tolerance needs.
+const maxFlattenDepth = 32
+
+// flattenMaxEvaluations caps the total number of recursive calls a
+// single top-level Flatten/FlattenTo invocation will make.
+// maxFlattenDepth alone doesn't bound the total work: with a branching
+// factor of 2, a small tol (even tol == 0, not just an adversarial
+// input) drives every leaf to maxFlattenDepth, and 2^maxFlattenDepth
+// calls is intractable. This budget guarantees termination regardless
+// of tol; ordinary curves at reasonable tolerances converge in well
+// under this many calls.
+const flattenMaxEvaluations = 65536
+
+// flattenDegenerateEpsilon is the squared-chord-length threshold below
+// which the start/end points of a subdivided curve are considered
+// coincident, so the normal flatness test (which divides by the chord
+// length) would be unstable.
+const flattenDegenerateEpsilon = 1e-12
+
+// defaultLengthFlattenTolerance is the flatness tolerance used by Length,
+// chosen to be visually exact at typical SVG scales.
+const defaultLengthFlattenTolerance = 0.01
+
+// recursionBudget caps the total number of recursive calls a single
+// top-level invocation of a subdivision-based algorithm will make, so
+// that a small or zero tolerance can't drive a depth-only cap into an
+// intractable 2^depth blowup; see flattenMaxEvaluations and its sibling
+// constants.
+type recursionBudget struct {
+	remaining int
+}
+
+func (b *recursionBudget) take() bool {
+	if b.remaining <= 0 {
+		return false
+	}
+	b.remaining--
+	return true
+}
+
+// A LineTracer receives the line segments produced by flattening a curve
+// into a polyline, one endpoint at a time.
+type LineTracer interface {
+	LineTo(Point)
+}
+
+// sliceLineTracer is a LineTracer that appends each traced point to a slice.
+type sliceLineTracer struct {
+	points *[]Point
+}
+
+func (s *sliceLineTracer) LineTo(p Point) {
+	*s.points = append(*s.points, p)
+}
+
+func midpoint(a, b Point) Point {
+	return Point{(a.X + b.X) / 2, (a.Y + b.Y) / 2}
+}
+
+func lerp(a, b Point, t float64) Point {
+	return Point{a.X + (b.X-a.X)*t, a.Y + (b.Y-a.Y)*t}
+}
+
+func squaredDistance(a, b Point) float64 {
+	dx := b.X - a.X
+	dy := b.Y - a.Y
+	return dx*dx + dy*dy
+}
+
+// cross returns the z-component of the 3D cross product of u and v
+// treated as vectors in the plane.
+func cross(u, v Point) float64 {
+	return u.X*v.Y - u.Y*v.X
+}
+
+// quadraticRootsInUnitInterval solves a*t^2 + b*t + c = 0, falling back to
+// the linear case when a is negligible, and keeps only roots in [0, 1].
+func quadraticRootsInUnitInterval(a, b, c float64) []float64 {
+	var roots []float64
+	if math.Abs(a) < flattenDegenerateEpsilon {
+		if math.Abs(b) < flattenDegenerateEpsilon {
+			return roots
+		}
+		if t := -c / b; t >= 0 && t <= 1 {
+			roots = append(roots, t)
+		}
+		return roots
+	}
+	discriminant := b*b - 4*a*c
+	if discriminant < 0 {
+		return roots
+	}
+	sqrtDiscriminant := math.Sqrt(discriminant)
+	if t := (-b + sqrtDiscriminant) / (2 * a); t >= 0 && t <= 1 {
+		roots = append(roots, t)
+	}
+	if t := (-b - sqrtDiscriminant) / (2 * a); t >= 0 && t <= 1 {
+		roots = append(roots, t)
+	}
+	sort.Float64s(roots)
+	return roots
+}
+
+func polylineLength(points []Point) float64 {
+	var length float64
+	for i := 1; i < len(points); i++ {
+		length += Line{points[i-1], points[i]}.Length()
+	}
+	return length
+}
+
+// arcLengthTableSamples is the number of equal-width intervals used to
+// build an arc-length lookup table; each interval's length is itself
+// computed with an 8-point Gauss-Legendre quadrature, so this only needs
+// to be large enough to capture curvature changes between samples.
+const arcLengthTableSamples = 100
+
+// gauss8Nodes and gauss8Weights are the abscissae and weights of the
+// 8-point Gauss-Legendre quadrature rule on [-1, 1].
+var gauss8Nodes = [8]float64{
+	-0.1834346424956498, 0.1834346424956498,
+	-0.5255324099163290, 0.5255324099163290,
+	-0.7966664774136267, 0.7966664774136267,
+	-0.9602898564975363, 0.9602898564975363,
+}
+var gauss8Weights = [8]float64{
+	0.3626837833783620, 0.3626837833783620,
+	0.3137066458778873, 0.3137066458778873,
+	0.2223810344533745, 0.2223810344533745,
+	0.1012285362903763, 0.1012285362903763,
+}
+
+// gaussLegendreIntegrate estimates the integral of |derivative(t)| over
+// [a, b] using the 8-point Gauss-Legendre rule.
+func gaussLegendreIntegrate(derivative func(t float64) Point, a, b float64) float64 {
+	mid := (a + b) / 2
+	halfWidth := (b - a) / 2
+	var sum float64
+	for i, node := range gauss8Nodes {
+		d := derivative(mid + halfWidth*node)
+		sum += gauss8Weights[i] * math.Hypot(d.X, d.Y)
+	}
+	return sum * halfWidth
+}
+
+// buildArcLengthTable returns the cumulative arc length of a curve at
+// arcLengthTableSamples+1 evenly spaced parameter values covering [0, 1].
+func buildArcLengthTable(derivative func(t float64) Point) []float64 {
+	table := make([]float64, arcLengthTableSamples+1)
+	step := 1.0 / float64(arcLengthTableSamples)
+	for i := 1; i <= arcLengthTableSamples; i++ {
+		table[i] = table[i-1] + gaussLegendreIntegrate(derivative, float64(i-1)*step, float64(i)*step)
+	}
+	return table
+}
+
+// lookupArcLength interpolates the cumulative length at parameter t from
+// an arc-length table built by buildArcLengthTable.
+func lookupArcLength(table []float64, t float64) float64 {
+	if t <= 0 {
+		return 0
+	}
+	n := len(table) - 1
+	if t >= 1 {
+		return table[n]
+	}
+	scaled := t * float64(n)
+	i := int(scaled)
+	frac := scaled - float64(i)
+	return table[i] + frac*(table[i+1]-table[i])
+}
+
+// inverseLookupArcLength finds the parameter t whose cumulative length is
+// s, interpolating between the bracketing samples of an arc-length table
+// built by buildArcLengthTable.
+func inverseLookupArcLength(table []float64, s float64) float64 {
+	n := len(table) - 1
+	total := table[n]
+	if s <= 0 || total <= 0 {
+		return 0
+	}
+	if s >= total {
+		return 1
+	}
+	i := sort.SearchFloat64s(table, s)
+	if i == 0 {
+		return 0
+	}
+	lo, hi := table[i-1], table[i]
+	frac := (s - lo) / (hi - lo)
+	return (float64(i-1) + frac) / float64(n)
+}
 
 // A QuadraticBezier represents a 2nd degree Bezier curve
 type QuadraticBezier struct {
@@ -21,12 +216,51 @@ func (q *QuadraticBezier) Bounds() Rect {
 
 // Length approximates the length of the curve.
 func (q *QuadraticBezier) Length() float64 {
-	var length float64
-	for t := float64(0); t < 1; t += quadLengthApproximationInterval {
-		segment := Line{q.Evaluate(t), q.Evaluate(t + quadLengthApproximationInterval)}
-		length += segment.Length()
+	return polylineLength(q.Flatten(defaultLengthFlattenTolerance))
+}
+
+// Flatten approximates the curve as a polyline, recursively subdividing
+// with de Casteljau's algorithm until each piece is flat to within tol,
+// or until maxFlattenDepth or flattenMaxEvaluations is reached, after
+// which pieces are emitted as they are.
+// The returned points start with q.Start and end with q.End.
+func (q *QuadraticBezier) Flatten(tol float64) []Point {
+	points := []Point{q.Start}
+	q.FlattenTo(&sliceLineTracer{&points}, tol)
+	return points
+}
+
+// FlattenTo is the streaming form of Flatten: it reports the endpoint of
+// each flattened segment to tracer instead of building a slice. The curve's
+// own start point is not reported; callers that need it already have q.Start.
+func (q *QuadraticBezier) FlattenTo(tracer LineTracer, tol float64) {
+	budget := &recursionBudget{remaining: flattenMaxEvaluations}
+	quadraticFlatten(tracer, q.Start, q.Control, q.End, tol, 0, budget)
+}
+
+func quadraticFlatten(tracer LineTracer, start, control, end Point, tol float64, depth int, budget *recursionBudget) {
+	if !budget.take() || depth >= maxFlattenDepth || quadraticIsFlat(start, control, end, tol) {
+		tracer.LineTo(end)
+		return
 	}
-	return length
+	m12 := midpoint(start, control)
+	m23 := midpoint(control, end)
+	m123 := midpoint(m12, m23)
+	quadraticFlatten(tracer, start, m12, m123, tol, depth+1, budget)
+	quadraticFlatten(tracer, m123, m23, end, tol, depth+1, budget)
+}
+
+// quadraticIsFlat approximates the flatness of a quadratic Bezier by the
+// perpendicular distance of its control point to the start->end chord.
+func quadraticIsFlat(start, control, end Point, tol float64) bool {
+	dx := end.X - start.X
+	dy := end.Y - start.Y
+	chordLengthSquared := dx*dx + dy*dy
+	if chordLengthSquared < flattenDegenerateEpsilon {
+		return squaredDistance(control, end) < tol*tol
+	}
+	d := (control.X-end.X)*dy - (control.Y-end.Y)*dx
+	return d*d < tol*tol*chordLengthSquared
 }
 
 // Evaluate gets a point on the bezier curve for a parameter between 0 and 1.
@@ -46,6 +280,116 @@ func (q *QuadraticBezier) To() Point {
 	return q.End
 }
 
+// SplitAt splits the curve at parameter t using de Casteljau's
+// construction, returning the two resulting curves. left.End and
+// right.Start are the same point by construction, not by re-evaluation.
+func (q *QuadraticBezier) SplitAt(t float64) (left, right QuadraticBezier) {
+	control1 := lerp(q.Start, q.Control, t)
+	control2 := lerp(q.Control, q.End, t)
+	split := lerp(control1, control2, t)
+	return QuadraticBezier{q.Start, control1, split}, QuadraticBezier{split, control2, q.End}
+}
+
+// Subdivide splits the curve at its midpoint; see SplitAt.
+func (q *QuadraticBezier) Subdivide() (left, right QuadraticBezier) {
+	return q.SplitAt(0.5)
+}
+
+// LengthAt approximates the arc length of the curve from its start up to
+// parameter t. Each call rebuilds the underlying arc-length table from
+// scratch; callers that need many queries against the same curve (e.g.
+// placing several markers, or animating along the path frame by frame)
+// should build that table once via ArcLength and query the returned
+// handle instead.
+func (q *QuadraticBezier) LengthAt(t float64) float64 {
+	return lookupArcLength(q.arcLengthTable(), t)
+}
+
+// TAtLength returns the parameter t at which the arc length of the curve
+// from its start is s, the inverse of LengthAt. See LengthAt's comment on
+// ArcLength for repeated queries against the same curve.
+func (q *QuadraticBezier) TAtLength(s float64) float64 {
+	return inverseLookupArcLength(q.arcLengthTable(), s)
+}
+
+// PointAtLength returns the point on the curve at arc length s from its
+// start, enabling even spacing of markers and animation along the path.
+// See LengthAt's comment on ArcLength for repeated queries against the
+// same curve.
+func (q *QuadraticBezier) PointAtLength(s float64) Point {
+	return q.Evaluate(q.TAtLength(s))
+}
+
+func (q *QuadraticBezier) arcLengthTable() []float64 {
+	return buildArcLengthTable(func(t float64) Point {
+		return quadraticBezierDerivative(q.Start, q.Control, q.End, t)
+	})
+}
+
+// QuadraticArcLength is an arc-length table precomputed for one curve, so
+// that repeated LengthAt/TAtLength/PointAtLength queries against it don't
+// each rebuild the table from scratch.
+type QuadraticArcLength struct {
+	curve QuadraticBezier
+	table []float64
+}
+
+// ArcLength precomputes q's arc-length table once for reuse; see
+// QuadraticArcLength.
+func (q *QuadraticBezier) ArcLength() *QuadraticArcLength {
+	return &QuadraticArcLength{curve: *q, table: q.arcLengthTable()}
+}
+
+// LengthAt approximates the arc length of the curve from its start up to
+// parameter t.
+func (a *QuadraticArcLength) LengthAt(t float64) float64 {
+	return lookupArcLength(a.table, t)
+}
+
+// TAtLength returns the parameter t at which the arc length of the curve
+// from its start is s, the inverse of LengthAt.
+func (a *QuadraticArcLength) TAtLength(s float64) float64 {
+	return inverseLookupArcLength(a.table, s)
+}
+
+// PointAtLength returns the point on the curve at arc length s from its
+// start.
+func (a *QuadraticArcLength) PointAtLength(s float64) Point {
+	return a.curve.Evaluate(a.TAtLength(s))
+}
+
+func quadraticBezierDerivative(start, control, end Point, t float64) Point {
+	x := quadraticBezierDerivativeComponent(start.X, control.X, end.X, t)
+	y := quadraticBezierDerivativeComponent(start.Y, control.Y, end.Y, t)
+	return Point{x, y}
+}
+
+func quadraticBezierDerivativeComponent(A, B, C, t float64) float64 {
+	return 2*(1-t)*(B-A) + 2*t*(C-B)
+}
+
+// ToCubic raises the curve's degree to a CubicBezier that traces exactly
+// the same path, via the standard control-point elevation formula.
+func (q *QuadraticBezier) ToCubic() CubicBezier {
+	control1 := Point{
+		q.Start.X + 2.0/3.0*(q.Control.X-q.Start.X),
+		q.Start.Y + 2.0/3.0*(q.Control.Y-q.Start.Y),
+	}
+	control2 := Point{
+		q.End.X + 2.0/3.0*(q.Control.X-q.End.X),
+		q.End.Y + 2.0/3.0*(q.Control.Y-q.End.Y),
+	}
+	return CubicBezier{q.Start, control1, control2, q.End}
+}
+
+// Offset approximates the curve offset by distance along its normal as a
+// sequence of cubic Beziers accurate to within tol; see
+// (*CubicBezier).Offset for the algorithm.
+func (q *QuadraticBezier) Offset(distance, tol float64) []CubicBezier {
+	cubic := q.ToCubic()
+	return cubic.Offset(distance, tol)
+}
+
 func quadraticBezierExtrema(A, B, C float64) (min, max float64) {
 	min = math.Min(A, C)
 	max = math.Max(A, C)
@@ -92,12 +436,58 @@ func (c *CubicBezier) Bounds() Rect {
 
 // Length approximates the length of the curve.
 func (c *CubicBezier) Length() float64 {
-	var length float64
-	for t := float64(0); t < 1; t += cubicLengthApproximationInterval {
-		segment := Line{c.Evaluate(t), c.Evaluate(t + cubicLengthApproximationInterval)}
-		length += segment.Length()
+	return polylineLength(c.Flatten(defaultLengthFlattenTolerance))
+}
+
+// Flatten approximates the curve as a polyline, recursively subdividing
+// with de Casteljau's algorithm until each piece is flat to within tol,
+// or until maxFlattenDepth or flattenMaxEvaluations is reached, after
+// which pieces are emitted as they are.
+// The returned points start with c.Start and end with c.End.
+func (c *CubicBezier) Flatten(tol float64) []Point {
+	points := []Point{c.Start}
+	c.FlattenTo(&sliceLineTracer{&points}, tol)
+	return points
+}
+
+// FlattenTo is the streaming form of Flatten: it reports the endpoint of
+// each flattened segment to tracer instead of building a slice. The curve's
+// own start point is not reported; callers that need it already have c.Start.
+func (c *CubicBezier) FlattenTo(tracer LineTracer, tol float64) {
+	budget := &recursionBudget{remaining: flattenMaxEvaluations}
+	cubicFlatten(tracer, c.Start, c.Control1, c.Control2, c.End, tol, 0, budget)
+}
+
+func cubicFlatten(tracer LineTracer, start, control1, control2, end Point, tol float64, depth int, budget *recursionBudget) {
+	if !budget.take() || depth >= maxFlattenDepth || cubicIsFlat(start, control1, control2, end, tol) {
+		tracer.LineTo(end)
+		return
 	}
-	return length
+	m12 := midpoint(start, control1)
+	m23 := midpoint(control1, control2)
+	m34 := midpoint(control2, end)
+	m123 := midpoint(m12, m23)
+	m234 := midpoint(m23, m34)
+	m1234 := midpoint(m123, m234)
+	cubicFlatten(tracer, start, m12, m123, m1234, tol, depth+1, budget)
+	cubicFlatten(tracer, m1234, m234, m34, end, tol, depth+1, budget)
+}
+
+// cubicIsFlat approximates the flatness of a cubic Bezier by the
+// perpendicular distance of its two control points to the start->end
+// chord, following the standard d2/d3 flatness test.
+func cubicIsFlat(start, control1, control2, end Point, tol float64) bool {
+	dx := end.X - start.X
+	dy := end.Y - start.Y
+	chordLengthSquared := dx*dx + dy*dy
+	if chordLengthSquared < flattenDegenerateEpsilon {
+		return squaredDistance(start, control1)+squaredDistance(control2, end) < tol*tol
+	}
+	d2 := (control1.X-end.X)*dy - (control1.Y-end.Y)*dx
+	d3 := (control2.X-end.X)*dy - (control2.Y-end.Y)*dx
+	d2 = math.Abs(d2)
+	d3 = math.Abs(d3)
+	return (d2+d3)*(d2+d3) < tol*tol*chordLengthSquared
 }
 
 // Evaluate gets a point on the bezier curve for a parameter between 0 and 1.
@@ -117,6 +507,351 @@ func (c *CubicBezier) To() Point {
 	return c.End
 }
 
+// SplitAt splits the curve at parameter t using de Casteljau's
+// construction, returning the two resulting curves. left.End and
+// right.Start are the same point by construction, not by re-evaluation.
+func (c *CubicBezier) SplitAt(t float64) (left, right CubicBezier) {
+	control1a := lerp(c.Start, c.Control1, t)
+	control1b := lerp(c.Control1, c.Control2, t)
+	control1c := lerp(c.Control2, c.End, t)
+	control2a := lerp(control1a, control1b, t)
+	control2b := lerp(control1b, control1c, t)
+	split := lerp(control2a, control2b, t)
+	return CubicBezier{c.Start, control1a, control2a, split}, CubicBezier{split, control2b, control1c, c.End}
+}
+
+// Subdivide splits the curve at its midpoint; see SplitAt.
+func (c *CubicBezier) Subdivide() (left, right CubicBezier) {
+	return c.SplitAt(0.5)
+}
+
+// LengthAt approximates the arc length of the curve from its start up to
+// parameter t. Each call rebuilds the underlying arc-length table from
+// scratch; callers that need many queries against the same curve (e.g.
+// placing several markers, or animating along the path frame by frame)
+// should build that table once via ArcLength and query the returned
+// handle instead.
+func (c *CubicBezier) LengthAt(t float64) float64 {
+	return lookupArcLength(c.arcLengthTable(), t)
+}
+
+// TAtLength returns the parameter t at which the arc length of the curve
+// from its start is s, the inverse of LengthAt. See LengthAt's comment on
+// ArcLength for repeated queries against the same curve.
+func (c *CubicBezier) TAtLength(s float64) float64 {
+	return inverseLookupArcLength(c.arcLengthTable(), s)
+}
+
+// PointAtLength returns the point on the curve at arc length s from its
+// start, enabling even spacing of markers and animation along the path.
+// See LengthAt's comment on ArcLength for repeated queries against the
+// same curve.
+func (c *CubicBezier) PointAtLength(s float64) Point {
+	return c.Evaluate(c.TAtLength(s))
+}
+
+func (c *CubicBezier) arcLengthTable() []float64 {
+	return buildArcLengthTable(func(t float64) Point {
+		return cubicBezierDerivative(c.Start, c.Control1, c.Control2, c.End, t)
+	})
+}
+
+// CubicArcLength is an arc-length table precomputed for one curve, so that
+// repeated LengthAt/TAtLength/PointAtLength queries against it don't each
+// rebuild the table from scratch.
+type CubicArcLength struct {
+	curve CubicBezier
+	table []float64
+}
+
+// ArcLength precomputes c's arc-length table once for reuse; see
+// CubicArcLength.
+func (c *CubicBezier) ArcLength() *CubicArcLength {
+	return &CubicArcLength{curve: *c, table: c.arcLengthTable()}
+}
+
+// LengthAt approximates the arc length of the curve from its start up to
+// parameter t.
+func (a *CubicArcLength) LengthAt(t float64) float64 {
+	return lookupArcLength(a.table, t)
+}
+
+// TAtLength returns the parameter t at which the arc length of the curve
+// from its start is s, the inverse of LengthAt.
+func (a *CubicArcLength) TAtLength(s float64) float64 {
+	return inverseLookupArcLength(a.table, s)
+}
+
+// PointAtLength returns the point on the curve at arc length s from its
+// start.
+func (a *CubicArcLength) PointAtLength(s float64) Point {
+	return a.curve.Evaluate(a.TAtLength(s))
+}
+
+func cubicBezierDerivative(start, control1, control2, end Point, t float64) Point {
+	x := cubicBezierDerivativeComponent(start.X, control1.X, control2.X, end.X, t)
+	y := cubicBezierDerivativeComponent(start.Y, control1.Y, control2.Y, end.Y, t)
+	return Point{x, y}
+}
+
+func cubicBezierDerivativeComponent(A, B, C, D, t float64) float64 {
+	return 3*math.Pow(1-t, 2)*(B-A) + 6*(1-t)*t*(C-B) + 3*t*t*(D-C)
+}
+
+// maxQuadraticApproximationDepth bounds the recursive subdivision in
+// ApproximateQuadratics, the same way maxFlattenDepth bounds Flatten.
+const maxQuadraticApproximationDepth = 32
+
+// quadraticApproximationMaxEvaluations caps the total number of
+// recursive calls a single top-level ApproximateQuadratics invocation
+// will make; see flattenMaxEvaluations for why the depth cap alone
+// isn't enough once tol can be arbitrarily small.
+const quadraticApproximationMaxEvaluations = 65536
+
+// cubicToQuadraticErrorConstant scales the magnitude of a cubic's "third
+// derivative" term into a bound on the maximum deviation introduced by
+// approximating it with a single quadratic; see ApproximateQuadratics.
+var cubicToQuadraticErrorConstant = math.Sqrt(3) / 36
+
+// ApproximateQuadratics decomposes the curve into a sequence of quadratic
+// Beziers, each within tol of the original curve. It recursively
+// subdivides wherever a single quadratic wouldn't be accurate enough,
+// which keeps simple curves as one segment while refining near sharp
+// corners. Once maxQuadraticApproximationDepth or
+// quadraticApproximationMaxEvaluations is reached, segments are emitted
+// without further subdivision and may exceed tol. This is needed by backends that only speak quadratics, such as
+// TrueType glyph output.
+func (c *CubicBezier) ApproximateQuadratics(tol float64) []QuadraticBezier {
+	budget := &recursionBudget{remaining: quadraticApproximationMaxEvaluations}
+	return cubicApproximateQuadratics(c.Start, c.Control1, c.Control2, c.End, tol, 0, budget)
+}
+
+func cubicApproximateQuadratics(start, control1, control2, end Point, tol float64, depth int, budget *recursionBudget) []QuadraticBezier {
+	if !budget.take() || depth >= maxQuadraticApproximationDepth || cubicToQuadraticError(start, control1, control2, end) <= tol {
+		return []QuadraticBezier{cubicToQuadraticApproximation(start, control1, control2, end)}
+	}
+	left, right := (&CubicBezier{start, control1, control2, end}).Subdivide()
+	quadratics := cubicApproximateQuadratics(left.Start, left.Control1, left.Control2, left.End, tol, depth+1, budget)
+	return append(quadratics, cubicApproximateQuadratics(right.Start, right.Control1, right.Control2, right.End, tol, depth+1, budget)...)
+}
+
+func cubicToQuadraticError(start, control1, control2, end Point) float64 {
+	dx := -start.X + 3*control1.X - 3*control2.X + end.X
+	dy := -start.Y + 3*control1.Y - 3*control2.Y + end.Y
+	return cubicToQuadraticErrorConstant * math.Hypot(dx, dy)
+}
+
+func cubicToQuadraticApproximation(start, control1, control2, end Point) QuadraticBezier {
+	control := Point{
+		(3*control1.X - start.X + 3*control2.X - end.X) / 4,
+		(3*control1.Y - start.Y + 3*control2.Y - end.Y) / 4,
+	}
+	return QuadraticBezier{start, control, end}
+}
+
+// Inflections returns the parameters in [0, 1] at which the curve's
+// curvature changes sign, i.e. the roots of B'(t) x B''(t) = 0. Offset
+// subdivides at these points because a single offset curve can't
+// represent a change of curvature side.
+func (c *CubicBezier) Inflections() []float64 {
+	a := Point{c.Control1.X - c.Start.X, c.Control1.Y - c.Start.Y}
+	b := Point{c.Control2.X - 2*c.Control1.X + c.Start.X, c.Control2.Y - 2*c.Control1.Y + c.Start.Y}
+	d := Point{c.End.X - 3*c.Control2.X + 3*c.Control1.X - c.Start.X, c.End.Y - 3*c.Control2.Y + 3*c.Control1.Y - c.Start.Y}
+
+	crossAB := cross(a, b)
+	crossAD := cross(a, d)
+	crossBD := cross(b, d)
+
+	// cross(B'(t), B''(t)) reduces to this quadratic in t once expanded
+	// in terms of a, b, d above.
+	return quadraticRootsInUnitInterval(crossBD, crossAD, crossAB)
+}
+
+// cuspRelativeThreshold scales the curve's overall size into a threshold
+// on |B'(t)|^2 below which a local minimum is treated as a cusp.
+const cuspRelativeThreshold = 1e-10
+
+// cuspDedupeRadius is the parameter-space distance within which two
+// detected cusps are considered the same cusp.
+const cuspDedupeRadius = 1e-4
+
+// Cusps returns the parameters in (0, 1) at which the curve's tangent
+// direction is undefined, found by locating interior local minima of
+// |B'(t)|^2 that are near zero. A true cusp has B'(t) == (0, 0) exactly,
+// which only closed-form coefficients can find for degenerate control
+// polygons, so this searches numerically instead.
+func (c *CubicBezier) Cusps() []float64 {
+	derivativeMagnitudeSquared := func(t float64) float64 {
+		d := cubicBezierDerivative(c.Start, c.Control1, c.Control2, c.End, t)
+		return d.X*d.X + d.Y*d.Y
+	}
+	scale := squaredDistance(c.Start, c.Control1) + squaredDistance(c.Control1, c.Control2) + squaredDistance(c.Control2, c.End)
+	threshold := scale * cuspRelativeThreshold
+
+	const samples = 64
+	var cusps []float64
+	for i := 0; i < samples; i++ {
+		lo, hi := float64(i)/samples, float64(i+1)/samples
+		if t, ok := goldenSectionMinimum(derivativeMagnitudeSquared, lo, hi); ok && derivativeMagnitudeSquared(t) < threshold {
+			cusps = append(cusps, t)
+		}
+	}
+	return dedupeFloats(cusps, cuspDedupeRadius)
+}
+
+// goldenSectionMinimum searches [a, b] for a local minimum of f. It
+// reports ok == false only if the point it converges to lies within 1e-9
+// of 0 or 1, the ends of the whole curve's parameter range; a point at
+// an end of [a, b] itself (f monotonic on the interval) is still
+// reported, and Cusps filters those out by their value of f.
+func goldenSectionMinimum(f func(float64) float64, a, b float64) (t float64, ok bool) {
+	const iterations = 40
+	const invPhi = 0.6180339887498949
+	c := b - invPhi*(b-a)
+	d := a + invPhi*(b-a)
+	for i := 0; i < iterations; i++ {
+		if f(c) < f(d) {
+			b = d
+		} else {
+			a = c
+		}
+		c = b - invPhi*(b-a)
+		d = a + invPhi*(b-a)
+	}
+	t = (a + b) / 2
+	if t <= 1e-9 || t >= 1-1e-9 {
+		return 0, false
+	}
+	return t, true
+}
+
+func dedupeFloats(values []float64, radius float64) []float64 {
+	sort.Float64s(values)
+	result := make([]float64, 0, len(values))
+	for _, v := range values {
+		if len(result) == 0 || v-result[len(result)-1] > radius {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// maxOffsetDepth bounds the recursive subdivision in Offset.
+const maxOffsetDepth = 32
+
+// offsetMaxEvaluations caps the total number of recursive calls a
+// single top-level Offset invocation will make per split segment; see
+// flattenMaxEvaluations for why the depth cap alone isn't enough once
+// tol can be arbitrarily small.
+const offsetMaxEvaluations = 65536
+
+// Offset approximates the curve offset by distance along its normal as a
+// sequence of cubic Beziers accurate to within tol. It first splits at
+// the curve's cusps and inflections, since those change which side the
+// curve bends on or make the normal undefined, then recursively
+// subdivides each piece until a single offset cubic built by translating
+// the control points along the endpoint normals tracks the true offset
+// (sampled at several points) to within tol; a piece that reaches
+// maxOffsetDepth or offsetMaxEvaluations is emitted as it is, even if
+// it exceeds tol. This is the core primitive
+// for stroking variable-width paths and building outlines.
+func (c *CubicBezier) Offset(distance, tol float64) []CubicBezier {
+	splitParams := append(append([]float64{}, c.Inflections()...), c.Cusps()...)
+	sort.Float64s(splitParams)
+
+	var offsets []CubicBezier
+	for _, segment := range splitCubicAt(*c, splitParams) {
+		budget := &recursionBudget{remaining: offsetMaxEvaluations}
+		offsets = append(offsets, cubicOffsetRecursive(segment, distance, tol, 0, budget)...)
+	}
+	return offsets
+}
+
+// splitCubicAt splits c at each parameter in params (which must be sorted
+// ascending), returning the resulting segments in order.
+func splitCubicAt(c CubicBezier, params []float64) []CubicBezier {
+	segments := make([]CubicBezier, 0, len(params)+1)
+	remaining := c
+	previousT := 0.0
+	for _, t := range params {
+		if t <= previousT || t >= 1 {
+			continue
+		}
+		left, right := remaining.SplitAt((t - previousT) / (1 - previousT))
+		segments = append(segments, left)
+		remaining = right
+		previousT = t
+	}
+	return append(segments, remaining)
+}
+
+func cubicOffsetRecursive(c CubicBezier, distance, tol float64, depth int, budget *recursionBudget) []CubicBezier {
+	candidate := cubicOffsetCandidate(c, distance)
+	if !budget.take() || depth >= maxOffsetDepth || cubicOffsetDeviation(c, candidate, distance) <= tol {
+		return []CubicBezier{candidate}
+	}
+	left, right := c.Subdivide()
+	offsets := cubicOffsetRecursive(left, distance, tol, depth+1, budget)
+	return append(offsets, cubicOffsetRecursive(right, distance, tol, depth+1, budget)...)
+}
+
+// cubicOffsetCandidate builds a single offset cubic by translating c's
+// start/Control1 along the normal at t=0 and its End/Control2 along the
+// normal at t=1, which preserves both endpoint tangent directions exactly.
+func cubicOffsetCandidate(c CubicBezier, distance float64) CubicBezier {
+	n0 := cubicNormalAt(c, 0)
+	n1 := cubicNormalAt(c, 1)
+	return CubicBezier{
+		Point{c.Start.X + distance*n0.X, c.Start.Y + distance*n0.Y},
+		Point{c.Control1.X + distance*n0.X, c.Control1.Y + distance*n0.Y},
+		Point{c.Control2.X + distance*n1.X, c.Control2.Y + distance*n1.Y},
+		Point{c.End.X + distance*n1.X, c.End.Y + distance*n1.Y},
+	}
+}
+
+// cubicNormalDegenerateNudge is how far inside the curve cubicNormalAt
+// looks for a usable tangent when the derivative is degenerate exactly
+// at t, e.g. a true cusp at an endpoint (Control1 == Start). It's small
+// enough that the recovered direction matches the curve's limiting
+// tangent as it leaves the cusp.
+const cubicNormalDegenerateNudge = 1e-4
+
+func cubicNormalAt(c CubicBezier, t float64) Point {
+	d := cubicBezierDerivative(c.Start, c.Control1, c.Control2, c.End, t)
+	length := math.Hypot(d.X, d.Y)
+	if length < flattenDegenerateEpsilon {
+		nudged := t + cubicNormalDegenerateNudge
+		if t > 0.5 {
+			nudged = t - cubicNormalDegenerateNudge
+		}
+		d = cubicBezierDerivative(c.Start, c.Control1, c.Control2, c.End, nudged)
+		length = math.Hypot(d.X, d.Y)
+		if length < flattenDegenerateEpsilon {
+			return Point{}
+		}
+	}
+	return Point{-d.Y / length, d.X / length}
+}
+
+// cubicOffsetDeviation samples the true offset curve (c's points pushed
+// out along c's own normal at each sample) against candidate, returning
+// the largest distance between them.
+func cubicOffsetDeviation(c, candidate CubicBezier, distance float64) float64 {
+	const samples = 8
+	var maxDeviation float64
+	for i := 1; i < samples; i++ {
+		t := float64(i) / samples
+		n := cubicNormalAt(c, t)
+		p := c.Evaluate(t)
+		truePoint := Point{p.X + distance*n.X, p.Y + distance*n.Y}
+		candidatePoint := candidate.Evaluate(t)
+		if deviation := math.Hypot(candidatePoint.X-truePoint.X, candidatePoint.Y-truePoint.Y); deviation > maxDeviation {
+			maxDeviation = deviation
+		}
+	}
+	return maxDeviation
+}
+
 func cubicBezierExtrema(A, B, C, D float64) []float64 {
 	// These coefficients result from taking the derivative of the cubic bezier
 	// polynomial.
@@ -143,3 +878,239 @@ func cubicBezierExtrema(A, B, C, D float64) []float64 {
 func cubicBezierPolynomial(A, B, C, D, t float64) float64 {
 	return A*math.Pow(1-t, 3) + 3*B*t*math.Pow(1-t, 2) + 3*C*(1-t)*t*t + D*t*t*t
 }
+
+// intersectionMaxDepth bounds the recursive bounding-box subdivision used
+// by the IntersectXX functions.
+const intersectionMaxDepth = 32
+
+// intersectionConvergedDiagonal is the bounding-box diagonal, in the same
+// units as the curves' points, below which two overlapping boxes are
+// treated as having converged on an intersection point.
+const intersectionConvergedDiagonal = 1e-6
+
+// intersectionDedupeRadius is the distance within which two reported
+// intersection points are considered the same point.
+const intersectionDedupeRadius = 1e-4
+
+// intersectionMaxEvaluations caps the total number of recursive calls a
+// single top-level IntersectXX call will make. intersectionMaxDepth alone
+// doesn't bound the total work: two curves that overlap along their
+// entire length (duplicate/retraced sub-paths are an ordinary input, not
+// a pathological one) keep all four subdivisions of every pair
+// overlapping, so the call count grows combinatorially with depth even
+// though each individual bounding box is shrinking normally. This budget
+// guarantees termination regardless of how the boxes behave; legitimate,
+// isolated intersections converge in well under this many calls.
+const intersectionMaxEvaluations = 20000
+
+// intersectionStallAreaRatio is how much smaller a child pair's combined
+// bounding-box area must be than its parent's for the subdivision to
+// count as making progress.
+const intersectionStallAreaRatio = 0.98
+
+// intersectionMaxStalls bounds how many consecutive non-shrinking splits
+// a branch is allowed before it's treated as converged and cut off.
+const intersectionMaxStalls = 8
+
+func rectsOverlap(a, b Rect) bool {
+	return a.Min.X <= b.Max.X && a.Max.X >= b.Min.X && a.Min.Y <= b.Max.Y && a.Max.Y >= b.Min.Y
+}
+
+func rectArea(r Rect) float64 {
+	return (r.Max.X - r.Min.X) * (r.Max.Y - r.Min.Y)
+}
+
+func rectDiagonal(r Rect) float64 {
+	return math.Hypot(r.Max.X-r.Min.X, r.Max.Y-r.Min.Y)
+}
+
+func rectCenter(r Rect) Point {
+	return midpoint(r.Min, r.Max)
+}
+
+func lineBounds(l Line) Rect {
+	return Rect{
+		Point{math.Min(l.Start.X, l.End.X), math.Min(l.Start.Y, l.End.Y)},
+		Point{math.Max(l.Start.X, l.End.X), math.Max(l.Start.Y, l.End.Y)},
+	}
+}
+
+func lineSubdivide(l Line) (left, right Line) {
+	mid := midpoint(l.Start, l.End)
+	return Line{l.Start, mid}, Line{mid, l.End}
+}
+
+// dedupePoints removes points that lie within radius of a point already
+// kept, preserving the order of first occurrence.
+func dedupePoints(points []Point, radius float64) []Point {
+	result := make([]Point, 0, len(points))
+	for _, p := range points {
+		duplicate := false
+		for _, kept := range result {
+			if squaredDistance(p, kept) < radius*radius {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// intersectionBudget caps the total number of recursive calls across a
+// whole top-level IntersectXX invocation; see intersectionMaxEvaluations.
+type intersectionBudget struct {
+	remaining int
+}
+
+func (b *intersectionBudget) take() bool {
+	if b.remaining <= 0 {
+		return false
+	}
+	b.remaining--
+	return true
+}
+
+// IntersectQQ returns the intersection points of two quadratic Beziers,
+// found via recursive bounding-box clipping: reject pairs of subcurves
+// whose boxes don't overlap, otherwise split both at their midpoint and
+// recurse on the four combinations until the boxes have converged on a
+// point, a branch stalls (see intersectionMaxStalls), or
+// intersectionMaxDepth or intersectionMaxEvaluations is reached.
+func IntersectQQ(a, b *QuadraticBezier) []Point {
+	budget := &intersectionBudget{remaining: intersectionMaxEvaluations}
+	return dedupePoints(intersectQQ(*a, *b, 0, budget, 0, 0), intersectionDedupeRadius)
+}
+
+func intersectQQ(a, b QuadraticBezier, depth int, budget *intersectionBudget, prevArea float64, stalls int) []Point {
+	if !budget.take() {
+		return nil
+	}
+	boundsA, boundsB := a.Bounds(), b.Bounds()
+	if !rectsOverlap(boundsA, boundsB) {
+		return nil
+	}
+	if depth >= intersectionMaxDepth || (rectDiagonal(boundsA) < intersectionConvergedDiagonal && rectDiagonal(boundsB) < intersectionConvergedDiagonal) {
+		return []Point{midpoint(rectCenter(boundsA), rectCenter(boundsB))}
+	}
+	area, stalls := trackIntersectionStall(rectArea(boundsA)+rectArea(boundsB), prevArea, stalls)
+	if stalls >= intersectionMaxStalls {
+		return []Point{midpoint(rectCenter(boundsA), rectCenter(boundsB))}
+	}
+	a1, a2 := a.Subdivide()
+	b1, b2 := b.Subdivide()
+	var points []Point
+	points = append(points, intersectQQ(a1, b1, depth+1, budget, area, stalls)...)
+	points = append(points, intersectQQ(a1, b2, depth+1, budget, area, stalls)...)
+	points = append(points, intersectQQ(a2, b1, depth+1, budget, area, stalls)...)
+	points = append(points, intersectQQ(a2, b2, depth+1, budget, area, stalls)...)
+	return points
+}
+
+// IntersectCC returns the intersection points of two cubic Beziers, via
+// the same recursive bounding-box clipping as IntersectQQ.
+func IntersectCC(a, b *CubicBezier) []Point {
+	budget := &intersectionBudget{remaining: intersectionMaxEvaluations}
+	return dedupePoints(intersectCC(*a, *b, 0, budget, 0, 0), intersectionDedupeRadius)
+}
+
+func intersectCC(a, b CubicBezier, depth int, budget *intersectionBudget, prevArea float64, stalls int) []Point {
+	if !budget.take() {
+		return nil
+	}
+	boundsA, boundsB := a.Bounds(), b.Bounds()
+	if !rectsOverlap(boundsA, boundsB) {
+		return nil
+	}
+	if depth >= intersectionMaxDepth || (rectDiagonal(boundsA) < intersectionConvergedDiagonal && rectDiagonal(boundsB) < intersectionConvergedDiagonal) {
+		return []Point{midpoint(rectCenter(boundsA), rectCenter(boundsB))}
+	}
+	area, stalls := trackIntersectionStall(rectArea(boundsA)+rectArea(boundsB), prevArea, stalls)
+	if stalls >= intersectionMaxStalls {
+		return []Point{midpoint(rectCenter(boundsA), rectCenter(boundsB))}
+	}
+	a1, a2 := a.Subdivide()
+	b1, b2 := b.Subdivide()
+	var points []Point
+	points = append(points, intersectCC(a1, b1, depth+1, budget, area, stalls)...)
+	points = append(points, intersectCC(a1, b2, depth+1, budget, area, stalls)...)
+	points = append(points, intersectCC(a2, b1, depth+1, budget, area, stalls)...)
+	points = append(points, intersectCC(a2, b2, depth+1, budget, area, stalls)...)
+	return points
+}
+
+// IntersectQL returns the intersection points of a quadratic Bezier and a
+// Line, via the same recursive bounding-box clipping as IntersectQQ.
+func IntersectQL(q *QuadraticBezier, l *Line) []Point {
+	budget := &intersectionBudget{remaining: intersectionMaxEvaluations}
+	return dedupePoints(intersectQL(*q, *l, 0, budget, 0, 0), intersectionDedupeRadius)
+}
+
+func intersectQL(q QuadraticBezier, l Line, depth int, budget *intersectionBudget, prevArea float64, stalls int) []Point {
+	if !budget.take() {
+		return nil
+	}
+	boundsQ, boundsL := q.Bounds(), lineBounds(l)
+	if !rectsOverlap(boundsQ, boundsL) {
+		return nil
+	}
+	if depth >= intersectionMaxDepth || (rectDiagonal(boundsQ) < intersectionConvergedDiagonal && rectDiagonal(boundsL) < intersectionConvergedDiagonal) {
+		return []Point{midpoint(rectCenter(boundsQ), rectCenter(boundsL))}
+	}
+	area, stalls := trackIntersectionStall(rectArea(boundsQ)+rectArea(boundsL), prevArea, stalls)
+	if stalls >= intersectionMaxStalls {
+		return []Point{midpoint(rectCenter(boundsQ), rectCenter(boundsL))}
+	}
+	q1, q2 := q.Subdivide()
+	l1, l2 := lineSubdivide(l)
+	var points []Point
+	points = append(points, intersectQL(q1, l1, depth+1, budget, area, stalls)...)
+	points = append(points, intersectQL(q1, l2, depth+1, budget, area, stalls)...)
+	points = append(points, intersectQL(q2, l1, depth+1, budget, area, stalls)...)
+	points = append(points, intersectQL(q2, l2, depth+1, budget, area, stalls)...)
+	return points
+}
+
+// IntersectCL returns the intersection points of a cubic Bezier and a
+// Line, via the same recursive bounding-box clipping as IntersectQQ.
+func IntersectCL(c *CubicBezier, l *Line) []Point {
+	budget := &intersectionBudget{remaining: intersectionMaxEvaluations}
+	return dedupePoints(intersectCL(*c, *l, 0, budget, 0, 0), intersectionDedupeRadius)
+}
+
+func intersectCL(c CubicBezier, l Line, depth int, budget *intersectionBudget, prevArea float64, stalls int) []Point {
+	if !budget.take() {
+		return nil
+	}
+	boundsC, boundsL := c.Bounds(), lineBounds(l)
+	if !rectsOverlap(boundsC, boundsL) {
+		return nil
+	}
+	if depth >= intersectionMaxDepth || (rectDiagonal(boundsC) < intersectionConvergedDiagonal && rectDiagonal(boundsL) < intersectionConvergedDiagonal) {
+		return []Point{midpoint(rectCenter(boundsC), rectCenter(boundsL))}
+	}
+	area, stalls := trackIntersectionStall(rectArea(boundsC)+rectArea(boundsL), prevArea, stalls)
+	if stalls >= intersectionMaxStalls {
+		return []Point{midpoint(rectCenter(boundsC), rectCenter(boundsL))}
+	}
+	c1, c2 := c.Subdivide()
+	l1, l2 := lineSubdivide(l)
+	var points []Point
+	points = append(points, intersectCL(c1, l1, depth+1, budget, area, stalls)...)
+	points = append(points, intersectCL(c1, l2, depth+1, budget, area, stalls)...)
+	points = append(points, intersectCL(c2, l1, depth+1, budget, area, stalls)...)
+	points = append(points, intersectCL(c2, l2, depth+1, budget, area, stalls)...)
+	return points
+}
+
+// trackIntersectionStall compares a pair's combined bounding-box area to
+// its parent's, returning the updated stall count: reset to zero when the
+// split meaningfully shrinks the area, incremented otherwise.
+func trackIntersectionStall(area, prevArea float64, stalls int) (float64, int) {
+	if prevArea > 0 && area > prevArea*intersectionStallAreaRatio {
+		return area, stalls + 1
+	}
+	return area, 0
+}
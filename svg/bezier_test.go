@@ -0,0 +1,281 @@
+package svg
+
+import (
+	"math"
+	"testing"
+)
+
+func TestQuadraticBezierFlattenEndpoints(t *testing.T) {
+	q := QuadraticBezier{Point{0, 0}, Point{5, 10}, Point{10, 0}}
+	points := q.Flatten(0.01)
+	if points[0] != q.Start {
+		t.Errorf("first point = %v, want %v", points[0], q.Start)
+	}
+	if got := points[len(points)-1]; got != q.End {
+		t.Errorf("last point = %v, want %v", got, q.End)
+	}
+}
+
+func TestCubicBezierFlattenDegenerateChord(t *testing.T) {
+	// Start and End coincide, so the chord used by the flatness test has
+	// zero length; Flatten must fall back to the squared control-point
+	// distances instead of dividing by it.
+	c := CubicBezier{
+		Start:    Point{0, 0},
+		Control1: Point{10, 10},
+		Control2: Point{-10, 10},
+		End:      Point{0, 0},
+	}
+	points := c.Flatten(0.01)
+	if points[0] != c.Start {
+		t.Errorf("first point = %v, want %v", points[0], c.Start)
+	}
+	if got := points[len(points)-1]; got != c.End {
+		t.Errorf("last point = %v, want %v", got, c.End)
+	}
+	if len(points) < 3 {
+		t.Errorf("degenerate-chord loop flattened to %d points, want it subdivided further", len(points))
+	}
+}
+
+func TestQuadraticBezierSplitAtEndpointIdentity(t *testing.T) {
+	q := QuadraticBezier{Point{0, 0}, Point{5, 10}, Point{10, 0}}
+	left, right := q.SplitAt(0.3)
+	if left.Start != q.Start {
+		t.Errorf("left.Start = %v, want %v", left.Start, q.Start)
+	}
+	if right.End != q.End {
+		t.Errorf("right.End = %v, want %v", right.End, q.End)
+	}
+	if left.End != right.Start {
+		t.Errorf("left.End = %v, right.Start = %v, want them exactly equal", left.End, right.Start)
+	}
+}
+
+const lengthRoundTripEpsilon = 1e-4
+
+func TestCubicBezierSubdivideEndpointIdentity(t *testing.T) {
+	c := CubicBezier{Point{0, 0}, Point{3, 9}, Point{7, 9}, Point{10, 0}}
+	left, right := c.Subdivide()
+	if left.Start != c.Start {
+		t.Errorf("left.Start = %v, want %v", left.Start, c.Start)
+	}
+	if right.End != c.End {
+		t.Errorf("right.End = %v, want %v", right.End, c.End)
+	}
+	if left.End != right.Start {
+		t.Errorf("left.End = %v, right.Start = %v, want them exactly equal", left.End, right.Start)
+	}
+}
+
+func TestQuadraticBezierLengthAtTAtLengthRoundTrip(t *testing.T) {
+	q := QuadraticBezier{Point{0, 0}, Point{5, 12}, Point{10, 0}}
+	total := q.LengthAt(1)
+	for _, s := range []float64{0, total * 0.25, total * 0.5, total * 0.75, total} {
+		tAt := q.TAtLength(s)
+		if got := q.LengthAt(tAt); math.Abs(got-s) > lengthRoundTripEpsilon {
+			t.Errorf("LengthAt(TAtLength(%v)) = %v, want %v", s, got, s)
+		}
+	}
+}
+
+func TestCubicArcLengthMatchesPerCallLengthAt(t *testing.T) {
+	c := CubicBezier{Point{0, 0}, Point{3, 9}, Point{7, 9}, Point{10, 0}}
+	handle := c.ArcLength()
+	for _, tVal := range []float64{0, 0.2, 0.5, 0.8, 1} {
+		want := c.LengthAt(tVal)
+		if got := handle.LengthAt(tVal); math.Abs(got-want) > lengthRoundTripEpsilon {
+			t.Errorf("ArcLength().LengthAt(%v) = %v, want %v (matching per-call LengthAt)", tVal, got, want)
+		}
+	}
+}
+
+func TestQuadraticBezierToCubicMatchesEvaluate(t *testing.T) {
+	q := QuadraticBezier{Point{0, 0}, Point{5, 10}, Point{10, 0}}
+	c := q.ToCubic()
+	for _, tVal := range []float64{0, 0.25, 0.5, 0.75, 1} {
+		want := q.Evaluate(tVal)
+		got := c.Evaluate(tVal)
+		if math.Hypot(got.X-want.X, got.Y-want.Y) > lengthRoundTripEpsilon {
+			t.Errorf("ToCubic().Evaluate(%v) = %v, want %v", tVal, got, want)
+		}
+	}
+}
+
+func TestCubicBezierApproximateQuadraticsWithinTolerance(t *testing.T) {
+	c := CubicBezier{Point{0, 0}, Point{0, 20}, Point{20, 20}, Point{20, 0}}
+	const tol = 0.01
+	quadratics := c.ApproximateQuadratics(tol)
+	if len(quadratics) == 0 {
+		t.Fatal("ApproximateQuadratics returned no segments")
+	}
+	if quadratics[0].Start != c.Start {
+		t.Errorf("first segment starts at %v, want %v", quadratics[0].Start, c.Start)
+	}
+	if last := quadratics[len(quadratics)-1].End; last != c.End {
+		t.Errorf("last segment ends at %v, want %v", last, c.End)
+	}
+	for i := 1; i < len(quadratics); i++ {
+		if quadratics[i-1].End != quadratics[i].Start {
+			t.Errorf("segment %d ends at %v, segment %d starts at %v, want them exactly equal", i-1, quadratics[i-1].End, i, quadratics[i].Start)
+		}
+	}
+
+	// Sample several matched parameters across the whole chain and check
+	// the quadratic approximation stays close to the original cubic.
+	for i, seg := range quadratics {
+		segStartT := float64(i) / float64(len(quadratics))
+		segEndT := float64(i+1) / float64(len(quadratics))
+		for _, frac := range []float64{0, 0.5, 1} {
+			localT := segStartT + frac*(segEndT-segStartT)
+			want := c.Evaluate(localT)
+			got := seg.Evaluate(frac)
+			if dist := math.Hypot(got.X-want.X, got.Y-want.Y); dist > tol {
+				t.Errorf("segment %d at local t=%v deviates by %v, want within %v", i, frac, dist, tol)
+			}
+		}
+	}
+}
+
+func TestIntersectCCCoincidentCurvesTerminates(t *testing.T) {
+	// Two identical curves overlap along their entire length, so every
+	// subdivision pair keeps overlapping boxes; this must terminate via
+	// the stall/budget cutoff in intersectCC rather than recursing
+	// combinatorially forever.
+	a := CubicBezier{Point{0, 0}, Point{3, 9}, Point{7, 9}, Point{10, 0}}
+	b := a
+	points := IntersectCC(&a, &b)
+	if len(points) == 0 {
+		t.Fatal("IntersectCC(a, a) returned no points, want at least one along the shared curve")
+	}
+}
+
+func TestCubicBezierCuspsKnownFixture(t *testing.T) {
+	// Constructed so the velocity B'(t) is exactly the zero vector at
+	// t=0.5: a genuine cusp, not just a sharp-looking bend.
+	c := CubicBezier{Point{0, 0}, Point{-1, 1}, Point{0, 1}, Point{-1, 0}}
+	cusps := c.Cusps()
+	if len(cusps) != 1 {
+		t.Fatalf("Cusps() = %v, want exactly one cusp", cusps)
+	}
+	if math.Abs(cusps[0]-0.5) > 1e-4 {
+		t.Errorf("cusp at t=%v, want t=0.5", cusps[0])
+	}
+
+	smooth := CubicBezier{Point{0, 0}, Point{3, 3}, Point{7, 3}, Point{10, 0}}
+	if got := smooth.Cusps(); len(got) != 0 {
+		t.Errorf("Cusps() on a smooth arc = %v, want none", got)
+	}
+}
+
+func TestCubicBezierInflectionsKnownFixture(t *testing.T) {
+	// A classic S-curve with a single inflection where the curve's bend
+	// switches sides, at t=0.5.
+	c := CubicBezier{Point{0, 0}, Point{0, 1}, Point{1, -1}, Point{1, 0}}
+	inflections := c.Inflections()
+	if len(inflections) != 1 {
+		t.Fatalf("Inflections() = %v, want exactly one inflection", inflections)
+	}
+	if math.Abs(inflections[0]-0.5) > 1e-4 {
+		t.Errorf("inflection at t=%v, want t=0.5", inflections[0])
+	}
+
+	arc := CubicBezier{Point{0, 0}, Point{3, 3}, Point{7, 3}, Point{10, 0}}
+	if got := arc.Inflections(); len(got) != 0 {
+		t.Errorf("Inflections() on a single-bend arc = %v, want none", got)
+	}
+}
+
+func TestCubicBezierOffsetTracksNormalsAtEndpoints(t *testing.T) {
+	c := CubicBezier{Point{0, 0}, Point{3, 3}, Point{7, 3}, Point{10, 0}}
+	const distance = 1.0
+	offsets := c.Offset(distance, 0.01)
+	if len(offsets) == 0 {
+		t.Fatal("Offset returned no segments")
+	}
+	for i := 1; i < len(offsets); i++ {
+		if offsets[i-1].End != offsets[i].Start {
+			t.Errorf("segment %d ends at %v, segment %d starts at %v, want them exactly equal", i-1, offsets[i-1].End, i, offsets[i].Start)
+		}
+	}
+
+	n0 := cubicNormalAt(c, 0)
+	wantStart := Point{c.Start.X + distance*n0.X, c.Start.Y + distance*n0.Y}
+	if got := offsets[0].Start; math.Hypot(got.X-wantStart.X, got.Y-wantStart.Y) > 1e-6 {
+		t.Errorf("first offset segment starts at %v, want %v", got, wantStart)
+	}
+
+	n1 := cubicNormalAt(c, 1)
+	wantEnd := Point{c.End.X + distance*n1.X, c.End.Y + distance*n1.Y}
+	if got := offsets[len(offsets)-1].End; math.Hypot(got.X-wantEnd.X, got.Y-wantEnd.Y) > 1e-6 {
+		t.Errorf("last offset segment ends at %v, want %v", got, wantEnd)
+	}
+}
+
+func TestQuadraticBezierFlattenNearZeroToleranceTerminates(t *testing.T) {
+	// depth alone doesn't bound the work: with a branching factor of 2,
+	// a near-zero tol drives every leaf to maxFlattenDepth, so this must
+	// terminate via the recursionBudget cutoff instead of recursing
+	// 2^maxFlattenDepth times.
+	q := QuadraticBezier{Point{0, 0}, Point{5, 10}, Point{10, 0}}
+	points := q.Flatten(0)
+	if points[0] != q.Start {
+		t.Errorf("first point = %v, want %v", points[0], q.Start)
+	}
+	if got := points[len(points)-1]; got != q.End {
+		t.Errorf("last point = %v, want %v", got, q.End)
+	}
+}
+
+func TestCubicBezierFlattenNearZeroToleranceTerminates(t *testing.T) {
+	c := CubicBezier{Point{0, 0}, Point{3, 9}, Point{7, 9}, Point{10, 0}}
+	points := c.Flatten(1e-15)
+	if points[0] != c.Start {
+		t.Errorf("first point = %v, want %v", points[0], c.Start)
+	}
+	if got := points[len(points)-1]; got != c.End {
+		t.Errorf("last point = %v, want %v", got, c.End)
+	}
+}
+
+func TestCubicBezierApproximateQuadraticsZeroToleranceTerminates(t *testing.T) {
+	// A depth-only cap drives tol=0 to recurse 2^maxQuadraticApproximationDepth
+	// times; this must terminate via the recursionBudget cutoff instead.
+	c := CubicBezier{Point{0, 0}, Point{0, 20}, Point{20, 20}, Point{20, 0}}
+	quadratics := c.ApproximateQuadratics(0)
+	if len(quadratics) == 0 {
+		t.Fatal("ApproximateQuadratics(0) returned no segments")
+	}
+	if quadratics[0].Start != c.Start {
+		t.Errorf("first segment starts at %v, want %v", quadratics[0].Start, c.Start)
+	}
+	if last := quadratics[len(quadratics)-1].End; last != c.End {
+		t.Errorf("last segment ends at %v, want %v", last, c.End)
+	}
+}
+
+func TestCubicBezierOffsetZeroToleranceTerminates(t *testing.T) {
+	// A depth-only cap drives tol=0 to recurse 2^maxOffsetDepth times per
+	// split segment; this must terminate via the recursionBudget cutoff.
+	c := CubicBezier{Point{0, 0}, Point{3, 3}, Point{7, 3}, Point{10, 0}}
+	offsets := c.Offset(1, 0)
+	if len(offsets) == 0 {
+		t.Fatal("Offset(1, 0) returned no segments")
+	}
+}
+
+func TestCubicBezierOffsetCuspAtEndpoint(t *testing.T) {
+	// Control1 == Start puts a true cusp exactly at t=0, where the direct
+	// derivative is the zero vector; the offset must still push the
+	// start point out by distance instead of leaving it unoffset.
+	c := CubicBezier{Point{0, 0}, Point{0, 0}, Point{5, 5}, Point{10, 0}}
+	const distance = 1.0
+	offsets := c.Offset(distance, 0.01)
+	if len(offsets) == 0 {
+		t.Fatal("Offset returned no segments")
+	}
+	start := offsets[0].Start
+	if dist := math.Hypot(start.X-c.Start.X, start.Y-c.Start.Y); math.Abs(dist-distance) > 1e-2 {
+		t.Errorf("offset start %v is %v away from the original start %v, want ~%v (a cusp at an endpoint must not suppress the offset)", start, dist, c.Start, distance)
+	}
+}